@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMergedConfigPrecedence(t *testing.T) {
+	tmp := t.TempDir()
+
+	configDir := filepath.Join(tmp, "xdgconfig")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	userCfgPath, err := userConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, userCfgPath, &Config{Profiles: map[string]Profile{
+		"work":     {ID: "work", GitUser: "user-work", GitEmail: "work@user.example"},
+		"personal": {ID: "personal", GitUser: "user-personal", GitEmail: "personal@user.example"},
+	}})
+
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "init")
+
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldwd) })
+
+	writeConfig(t, filepath.Join(repo, ".gitprofile.json"), &Config{Profiles: map[string]Profile{
+		"work": {ID: "work", GitUser: "repo-work", GitEmail: "work@repo.example"},
+	}})
+
+	merged, origin, err := loadMergedConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := merged.Profiles["work"].GitEmail; got != "work@repo.example" {
+		t.Errorf("local layer should override user layer for %q, got email %q", "work", got)
+	}
+	if got, want := origin["work"], "local:"; !strings.HasPrefix(got, want) {
+		t.Errorf("origin[%q] = %q, want prefix %q", "work", got, want)
+	}
+
+	if got := merged.Profiles["personal"].GitEmail; got != "personal@user.example" {
+		t.Errorf("profile only present in user layer should pass through unchanged, got %q", got)
+	}
+	if got, want := origin["personal"], "user:"; !strings.HasPrefix(got, want) {
+		t.Errorf("origin[%q] = %q, want prefix %q", "personal", got, want)
+	}
+}
+
+func writeConfig(t *testing.T, path string, cfg *Config) {
+	t.Helper()
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"git@github.com:acme/*.git", "git@github.com:acme/widgets.git", true},
+		{"git@github.com:acme/*.git", "git@github.com:acme/widgets/extra.git", false}, // "*" doesn't cross "/"
+		{"git@github.com:acme/**", "git@github.com:acme/widgets/extra.git", true},     // "**" does
+		{"/home/user/work/**", "/home/user/work/repo", true},
+		{"/home/user/work/**", "/home/user/personal/repo", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file12.txt", false},
+	}
+
+	for _, c := range cases {
+		got, err := globMatch(c.pattern, c.value)
+		if err != nil {
+			t.Fatalf("globMatch(%q, %q): %v", c.pattern, c.value, err)
+		}
+		if got != c.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", c.pattern, c.value, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsZeroOID(t *testing.T) {
+	cases := []struct {
+		oid  string
+		want bool
+	}{
+		{"0000000000000000000000000000000000000000", true},
+		{"0000000000000000000000000000000000000000000000000000000000000000", true}, // sha256 zero
+		{"0", true},
+		{"abc123", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := isZeroOID(c.oid); got != c.want {
+			t.Errorf("isZeroOID(%q) = %v, want %v", c.oid, got, c.want)
+		}
+	}
+}
+
+// TestCommitEmailsForPushNewRef guards the `77e7f99` hotfix: pushing a
+// brand-new ref must only report commits not already reachable from a
+// remote-tracking branch, not the whole ancestry.
+func TestCommitEmailsForPushNewRef(t *testing.T) {
+	tmp := t.TempDir()
+	remote := filepath.Join(tmp, "remote.git")
+	runGit(t, tmp, "init", "--bare", remote)
+
+	work := filepath.Join(tmp, "work")
+	runGit(t, tmp, "clone", remote, work)
+
+	writeAndCommit(t, work, "README.md", "hello", "old@example.com")
+	runGit(t, work, "push", "origin", "HEAD:main")
+
+	runGit(t, work, "checkout", "-b", "feature")
+	writeAndCommit(t, work, "feature.txt", "new stuff", "new@example.com")
+
+	featureHead := strings.TrimSpace(runGitOutput(t, work, "rev-parse", "HEAD"))
+
+	emails, err := commitEmailsForPushIn(work, featureHead, zeroOIDFor(featureHead))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(emails) != 1 || emails[0] != "new@example.com" {
+		t.Errorf("commitEmailsForPush on a new ref = %v, want only the new commit's author (the remote-tracked ancestor must be excluded)", emails)
+	}
+}
+
+// zeroOIDFor returns an all-zero OID the same length as a real one, as
+// git itself does to signal "no ref on this side".
+func zeroOIDFor(oid string) string {
+	return strings.Repeat("0", len(oid))
+}
+
+// commitEmailsForPushIn runs commitEmailsForPush with the working
+// directory set to dir, since it shells out to plain `git` (no repo path
+// argument).
+func commitEmailsForPushIn(dir, localOID, remoteOID string) ([]string, error) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Chdir(oldwd)
+	if err := os.Chdir(dir); err != nil {
+		return nil, err
+	}
+	return commitEmailsForPush(localOID, remoteOID)
+}
+
+func runGitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return string(out)
+}
+
+func writeAndCommit(t *testing.T, repo, name, content, email string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("git", "add", name)
+	cmd.Dir = repo
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "commit", "-m", "add "+name)
+	cmd.Dir = repo
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL="+email, "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL="+email)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 )
@@ -18,6 +23,30 @@ type Profile struct {
 	GitUser    string `json:"git_user"`
 	GitEmail   string `json:"git_email"`
 	SSHKeyPath string `json:"ssh_key_path,omitempty"`
+
+	// Commit/tag signing. SigningFormat is one of "openpgp" or "ssh".
+	SigningKey         string `json:"signing_key,omitempty"`
+	SigningFormat      string `json:"signing_format,omitempty"`
+	SignCommits        bool   `json:"sign_commits,omitempty"`
+	AllowedSignersFile string `json:"allowed_signers_file,omitempty"`
+
+	// Match lets `ensure` auto-select this profile by remote URL or
+	// working-directory glob, without prompting or consulting defaults.
+	// A pointer so omitempty actually suppresses it for profiles that
+	// don't use matching (encoding/json's omitempty has no effect on
+	// struct-typed fields).
+	Match *MatchRules `json:"match,omitempty"`
+}
+
+// MatchRules holds the glob patterns that auto-select a profile in
+// cmdEnsure. Patterns support '*' (any run of non-slash characters),
+// '**' (any run of characters, including slashes) and '?' (one
+// character). A profile matches if any remote pattern matches
+// `remote.origin.url` or any path pattern matches the current working
+// directory.
+type MatchRules struct {
+	RemotePatterns []string `json:"remote_patterns,omitempty"`
+	PathPatterns   []string `json:"path_patterns,omitempty"`
 }
 
 type Config struct {
@@ -25,8 +54,15 @@ type Config struct {
 }
 
 // ----- Config file handling -----
+//
+// Profiles are layered across three sources, lowest to highest
+// precedence: a system-wide file, the per-user file, and a repo-local
+// ".gitprofile.json" (found by walking up from the CWD to the git
+// worktree root). Later layers override earlier ones by profile ID.
+
+const systemConfigPath = "/etc/gitprofile/config.json"
 
-func defaultConfigPath() (string, error) {
+func userConfigPath() (string, error) {
 	cfgDir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
@@ -38,14 +74,77 @@ func defaultConfigPath() (string, error) {
 	return filepath.Join(dir, "config.json"), nil
 }
 
-func loadConfig() (*Config, string, error) {
-	path, err := defaultConfigPath()
+// findLocalConfigPath looks for ".gitprofile.json", starting at the CWD
+// and walking up to the git worktree root. It returns the path of the
+// closest one found, or the worktree-root path (for writing a new one)
+// if none exists. The bool reports whether a file was actually found.
+func findLocalConfigPath() (string, bool) {
+	root, err := gitWorktreeRoot()
+	if err != nil {
+		return "", false
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".gitprofile.json")
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+		if dir == root {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return filepath.Join(root, ".gitprofile.json"), false
+}
+
+func gitWorktreeRoot() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// scopeConfigPath returns the config file path for a scope: "system",
+// "local", or anything else (treated as "user", the default).
+func scopeConfigPath(scope string) (string, error) {
+	switch scope {
+	case "system":
+		return systemConfigPath, nil
+	case "local":
+		path, _ := findLocalConfigPath()
+		return path, nil
+	default:
+		return userConfigPath()
+	}
+}
+
+// loadScopeConfig loads a single scope's config file. A missing file
+// (or, for "local" outside a git repo, no resolvable path) yields an
+// empty config rather than an error.
+func loadScopeConfig(scope string) (*Config, string, error) {
+	path, err := scopeConfigPath(scope)
 	if err != nil {
 		return nil, "", err
 	}
 
 	cfg := &Config{Profiles: make(map[string]Profile)}
 
+	if path == "" {
+		return cfg, path, nil
+	}
+
 	f, err := os.Open(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -66,7 +165,61 @@ func loadConfig() (*Config, string, error) {
 	return cfg, path, nil
 }
 
+// loadMergedConfig merges all three layers (system, user, local) into a
+// single view, later layers overriding earlier ones by profile ID. The
+// returned origin map records "scope:path" for each profile ID.
+func loadMergedConfig() (*Config, map[string]string, error) {
+	merged := &Config{Profiles: make(map[string]Profile)}
+	origin := make(map[string]string)
+
+	for _, scope := range []string{"system", "user", "local"} {
+		cfg, path, err := loadScopeConfig(scope)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading %s config: %w", scope, err)
+		}
+		for id, p := range cfg.Profiles {
+			merged.Profiles[id] = p
+			origin[id] = scope + ":" + path
+		}
+	}
+
+	return merged, origin, nil
+}
+
+// profileScope resolves which scope ("system", "user" or "local") a
+// profile currently lives in, so edits land back in the right file.
+func profileScope(id string) (string, error) {
+	_, origin, err := loadMergedConfig()
+	if err != nil {
+		return "", err
+	}
+	o, ok := origin[id]
+	if !ok {
+		return "", fmt.Errorf("profile %q not found", id)
+	}
+	scope, _, _ := strings.Cut(o, ":")
+	return scope, nil
+}
+
+// localImplicitProfile returns the sole profile in the repo-local
+// .gitprofile.json, if that file exists and defines exactly one. Such a
+// file is treated as an unambiguous default by `ensure`.
+func localImplicitProfile() (Profile, bool) {
+	cfg, _, err := loadScopeConfig("local")
+	if err != nil || len(cfg.Profiles) != 1 {
+		return Profile{}, false
+	}
+	for _, p := range cfg.Profiles {
+		return p, true
+	}
+	return Profile{}, false
+}
+
 func saveConfig(cfg *Config, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
 	tmp := path + ".tmp"
 	f, err := os.Create(tmp)
 	if err != nil {
@@ -99,6 +252,15 @@ func runGitConfig(scope string, key string, value string) error {
 	return cmd.Run()
 }
 
+func unsetGitConfig(scope string, key string) {
+	args := []string{"config"}
+	if scope == "global" {
+		args = append(args, "--global")
+	}
+	args = append(args, "--unset", key)
+	_ = exec.Command("git", args...).Run()
+}
+
 func getGitConfig(key string) (string, error) {
 	cmd := exec.Command("git", "config", "--get", key)
 	out, err := cmd.Output()
@@ -135,9 +297,150 @@ func applyProfile(p Profile, scope string) error {
 		_ = exec.Command("git", "config", "--unset", "core.sshCommand").Run()
 	}
 
+	if err := applySigning(p, scope); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// applySigning sets (or clears) the signing-related git config for a
+// profile. Profiles that don't sign get their signing keys cleared at
+// whatever scope is being applied to, so a previously-signing identity
+// doesn't leak into the next at that same scope.
+func applySigning(p Profile, scope string) error {
+	if p.SigningKey == "" && !p.SignCommits {
+		unsetGitConfig(scope, "user.signingKey")
+		unsetGitConfig(scope, "gpg.format")
+		unsetGitConfig(scope, "gpg.ssh.allowedSignersFile")
+		unsetGitConfig(scope, "commit.gpgsign")
+		unsetGitConfig(scope, "tag.gpgsign")
+		return nil
+	}
+
+	if p.SigningKey != "" {
+		if err := runGitConfig(scope, "user.signingKey", p.SigningKey); err != nil {
+			return fmt.Errorf("setting user.signingKey: %w", err)
+		}
+	}
+
+	format := p.SigningFormat
+	if format == "" {
+		format = "openpgp"
+	}
+	if err := runGitConfig(scope, "gpg.format", format); err != nil {
+		return fmt.Errorf("setting gpg.format: %w", err)
+	}
+
+	if format == "ssh" && p.AllowedSignersFile != "" {
+		if err := runGitConfig(scope, "gpg.ssh.allowedSignersFile", p.AllowedSignersFile); err != nil {
+			return fmt.Errorf("setting gpg.ssh.allowedSignersFile: %w", err)
+		}
+	}
+
+	sign := "false"
+	if p.SignCommits {
+		sign = "true"
+	}
+	if err := runGitConfig(scope, "commit.gpgsign", sign); err != nil {
+		return fmt.Errorf("setting commit.gpgsign: %w", err)
+	}
+	if err := runGitConfig(scope, "tag.gpgsign", sign); err != nil {
+		return fmt.Errorf("setting tag.gpgsign: %w", err)
+	}
+
+	return nil
+}
+
+// ----- Glob matching for `match` rules -----
+
+// globMatch reports whether value matches a shell-style glob pattern.
+// Unlike filepath.Match, "**" matches across path separators.
+func globMatch(pattern, value string) (bool, error) {
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(value), nil
+}
+
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			b.WriteString(".")
+			i++
+		default:
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			i++
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// expandHome replaces a leading "~" in pattern with the user's home
+// directory, so path patterns can be written like `~/work/**`.
+func expandHome(pattern string) string {
+	if pattern != "~" && !strings.HasPrefix(pattern, "~/") {
+		return pattern
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return pattern
+	}
+	if pattern == "~" {
+		return home
+	}
+	return filepath.Join(home, pattern[2:])
+}
+
+// matchProfile returns the first profile (in ID order, for determinism)
+// whose Match rules match the current remote URL or working directory.
+func matchProfile(cfg *Config) (Profile, bool) {
+	remote, _ := getGitConfig("remote.origin.url")
+	cwd, _ := os.Getwd()
+
+	ids := make([]string, 0, len(cfg.Profiles))
+	for id := range cfg.Profiles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		p := cfg.Profiles[id]
+		if p.Match == nil {
+			continue
+		}
+		for _, pat := range p.Match.RemotePatterns {
+			if remote == "" {
+				continue
+			}
+			if ok, err := globMatch(pat, remote); err == nil && ok {
+				return p, true
+			}
+		}
+		for _, pat := range p.Match.PathPatterns {
+			if cwd == "" {
+				continue
+			}
+			if ok, err := globMatch(expandHome(pat), cwd); err == nil && ok {
+				return p, true
+			}
+		}
+	}
+
+	return Profile{}, false
+}
+
 func gitDir() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
 	out, err := cmd.Output()
@@ -155,41 +458,202 @@ func cmdAdd(args []string) error {
 	name := fs.String("name", "", "Git user.name")
 	email := fs.String("email", "", "Git user.email")
 	sshKey := fs.String("ssh-key", "", "SSH key path (optional)")
+	signKey := fs.String("sign-key", "", "Signing key (GPG key ID or path to SSH public key)")
+	signFormat := fs.String("sign-format", "", "Signing format: openpgp or ssh (default openpgp)")
+	sign := fs.Bool("sign", false, "Sign commits and tags with this profile")
+	allowedSigners := fs.String("allowed-signers", "", "Path to an SSH allowed_signers file (--sign-format ssh only)")
+	generateSSH := fs.Bool("generate-ssh", false, "Generate a new SSH keypair for this profile")
+	sshType := fs.String("ssh-type", "ed25519", "Key type for --generate-ssh: ed25519 or rsa")
+	sshComment := fs.String("ssh-comment", "", "Comment embedded in the generated public key (default: the profile's email)")
+	sshPassphrase := fs.String("ssh-passphrase", "", "Passphrase for the generated key (default: none). "+
+		"Passed to ssh-keygen as a plain argv entry, so on a shared machine other local users can read it "+
+		"via ps/procfs for the life of the subprocess; prefer -N '' here and set a passphrase afterward with "+
+		"'ssh-keygen -p' if the key needs one")
+	scope := fs.String("scope", "user", "Where to store the profile: user, local or system")
 	_ = fs.Parse(args)
 
 	if *id == "" || *name == "" || *email == "" {
 		return fmt.Errorf("id, name and email are required")
 	}
+	if *signFormat != "" && *signFormat != "openpgp" && *signFormat != "ssh" {
+		return fmt.Errorf("--sign-format must be %q or %q", "openpgp", "ssh")
+	}
+	if *generateSSH && *sshKey != "" {
+		return fmt.Errorf("--generate-ssh and --ssh-key are mutually exclusive")
+	}
+	if *sshType != "ed25519" && *sshType != "rsa" {
+		return fmt.Errorf("--ssh-type must be %q or %q", "ed25519", "rsa")
+	}
+	if *scope != "user" && *scope != "local" && *scope != "system" {
+		return fmt.Errorf("--scope must be %q, %q or %q", "user", "local", "system")
+	}
 
-	cfg, path, err := loadConfig()
+	cfg, path, err := loadScopeConfig(*scope)
 	if err != nil {
 		return err
 	}
+	if path == "" {
+		return fmt.Errorf("--scope local requires running inside a git repository")
+	}
 
 	if _, exists := cfg.Profiles[*id]; exists {
-		return fmt.Errorf("profile %q already exists", *id)
+		return fmt.Errorf("profile %q already exists in %s scope", *id, *scope)
 	}
 
 	p := Profile{
-		ID:         *id,
-		GitUser:    *name,
-		GitEmail:   *email,
-		SSHKeyPath: *sshKey,
+		ID:                 *id,
+		GitUser:            *name,
+		GitEmail:           *email,
+		SSHKeyPath:         *sshKey,
+		SigningKey:         *signKey,
+		SigningFormat:      *signFormat,
+		SignCommits:        *sign,
+		AllowedSignersFile: *allowedSigners,
+	}
+
+	if *generateSSH {
+		comment := *sshComment
+		if comment == "" {
+			comment = *email
+		}
+		keyPath, err := generateSSHKeypair(*id, *sshType, comment, *sshPassphrase)
+		if err != nil {
+			return err
+		}
+		p.SSHKeyPath = keyPath
+
+		pub, err := os.ReadFile(keyPath + ".pub")
+		if err != nil {
+			return fmt.Errorf("reading generated public key: %w", err)
+		}
+		fmt.Printf("Generated SSH key %s\n", keyPath)
+		fmt.Print(string(pub))
 	}
+
 	cfg.Profiles[*id] = p
 
 	if err := saveConfig(cfg, path); err != nil {
 		return err
 	}
 
-	fmt.Printf("Added profile %q\n", *id)
+	fmt.Printf("Added profile %q (%s scope)\n", *id, *scope)
+	return nil
+}
+
+// generateSSHKeypair creates a new keypair at ~/.ssh/gitprofile_<id> via
+// ssh-keygen and returns the path to the private key.
+//
+// passphrase is passed to ssh-keygen as a literal -N argv entry, which is
+// readable by other local users via ps/procfs for the life of the
+// subprocess. Callers that care about that exposure should pass "" and
+// set a passphrase afterward with `ssh-keygen -p`, which prompts instead.
+func generateSSHKeypair(id, keyType, comment, passphrase string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0o700); err != nil {
+		return "", err
+	}
+
+	keyPath := filepath.Join(sshDir, "gitprofile_"+id)
+	if _, err := os.Stat(keyPath); err == nil {
+		return "", fmt.Errorf("key already exists: %s", keyPath)
+	}
+
+	cmd := exec.Command("ssh-keygen",
+		"-t", keyType,
+		"-f", keyPath,
+		"-N", passphrase,
+		"-C", comment,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ssh-keygen: %w", err)
+	}
+
+	return keyPath, nil
+}
+
+// edit: update fields on an existing profile. Only flags explicitly
+// passed are changed; everything else is left as-is.
+func cmdEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	id := fs.String("id", "", "Profile ID to edit")
+	name := fs.String("name", "", "Git user.name")
+	email := fs.String("email", "", "Git user.email")
+	sshKey := fs.String("ssh-key", "", "SSH key path")
+	signKey := fs.String("sign-key", "", "Signing key (GPG key ID or path to SSH public key)")
+	signFormat := fs.String("sign-format", "", "Signing format: openpgp or ssh")
+	sign := fs.Bool("sign", false, "Sign commits and tags with this profile")
+	noSign := fs.Bool("no-sign", false, "Stop signing commits and tags with this profile")
+	allowedSigners := fs.String("allowed-signers", "", "Path to an SSH allowed_signers file (--sign-format ssh only)")
+	_ = fs.Parse(args)
+
+	if *id == "" {
+		return fmt.Errorf("--id is required")
+	}
+	if *signFormat != "" && *signFormat != "openpgp" && *signFormat != "ssh" {
+		return fmt.Errorf("--sign-format must be %q or %q", "openpgp", "ssh")
+	}
+
+	scope, err := profileScope(*id)
+	if err != nil {
+		return err
+	}
+
+	cfg, path, err := loadScopeConfig(scope)
+	if err != nil {
+		return err
+	}
+
+	p, ok := cfg.Profiles[*id]
+	if !ok {
+		return fmt.Errorf("profile %q not found", *id)
+	}
+
+	if *name != "" {
+		p.GitUser = *name
+	}
+	if *email != "" {
+		p.GitEmail = *email
+	}
+	if *sshKey != "" {
+		p.SSHKeyPath = *sshKey
+	}
+	if *signKey != "" {
+		p.SigningKey = *signKey
+	}
+	if *signFormat != "" {
+		p.SigningFormat = *signFormat
+	}
+	if *allowedSigners != "" {
+		p.AllowedSignersFile = *allowedSigners
+	}
+	if *sign {
+		p.SignCommits = true
+	}
+	if *noSign {
+		p.SignCommits = false
+	}
+
+	cfg.Profiles[*id] = p
+
+	if err := saveConfig(cfg, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated profile %q\n", *id)
 	return nil
 }
 
 func cmdList(args []string) error {
 	_ = args
 
-	cfg, _, err := loadConfig()
+	cfg, origin, err := loadMergedConfig()
 	if err != nil {
 		return err
 	}
@@ -212,7 +676,12 @@ func cmdList(args []string) error {
 		if ssh == "" {
 			ssh = "(default SSH)"
 		}
-		fmt.Printf("  - %s: %s <%s>, ssh=%s\n", id, p.GitUser, p.GitEmail, ssh)
+		sign := "no"
+		if p.SignCommits {
+			sign = "yes"
+		}
+		scope, _, _ := strings.Cut(origin[id], ":")
+		fmt.Printf("  - %s: %s <%s>, ssh=%s, sign=%s, scope=%s\n", id, p.GitUser, p.GitEmail, ssh, sign, scope)
 	}
 	return nil
 }
@@ -227,7 +696,7 @@ func cmdUse(args []string) error {
 	}
 	id := fs.Arg(0)
 
-	cfg, _, err := loadConfig()
+	cfg, _, err := loadMergedConfig()
 	if err != nil {
 		return err
 	}
@@ -275,6 +744,22 @@ func cmdCurrent(args []string) error {
 		fmt.Println("  core.sshCommand = (default)")
 	}
 
+	if signingKey, err := getGitConfig("user.signingKey"); err == nil && signingKey != "" {
+		fmt.Printf("  user.signingKey = %s\n", signingKey)
+	}
+	if format, err := getGitConfig("gpg.format"); err == nil && format != "" {
+		fmt.Printf("  gpg.format = %s\n", format)
+	}
+	if allowedSigners, err := getGitConfig("gpg.ssh.allowedSignersFile"); err == nil && allowedSigners != "" {
+		fmt.Printf("  gpg.ssh.allowedSignersFile = %s\n", allowedSigners)
+	}
+	if gpgsign, err := getGitConfig("commit.gpgsign"); err == nil && gpgsign != "" {
+		fmt.Printf("  commit.gpgsign = %s\n", gpgsign)
+	}
+	if tagSign, err := getGitConfig("tag.gpgsign"); err == nil && tagSign != "" {
+		fmt.Printf("  tag.gpgsign = %s\n", tagSign)
+	}
+
 	def, errDef := getGitConfig("gitprofile.default")
 	if errDef == nil && def != "" {
 		fmt.Printf("  gitprofile.default (local) = %s\n", def)
@@ -288,7 +773,7 @@ func cmdCurrent(args []string) error {
 }
 
 func cmdChoose(_ []string) error {
-	cfg, _, err := loadConfig()
+	cfg, _, err := loadMergedConfig()
 	if err != nil {
 		return err
 	}
@@ -345,7 +830,7 @@ func cmdSetDefault(args []string) error {
 	}
 	id := fs.Arg(0)
 
-	cfg, _, err := loadConfig()
+	cfg, _, err := loadMergedConfig()
 	if err != nil {
 		return err
 	}
@@ -367,40 +852,260 @@ func cmdSetDefault(args []string) error {
 	return nil
 }
 
-// ensure: used by hooks
-// 1) If local gitprofile.default exists and matches a profile -> apply it
-// 2) Else if global gitprofile.default exists and matches -> apply it
-// 3) Else -> interactive choose()
-func cmdEnsure(args []string) error {
-	_ = args
-
-	cfg, _, err := loadConfig()
+// expectedIdentity resolves the profile that should be active in the
+// current repo, using the same precedence as `ensure`, but without ever
+// prompting: a repo-local single-profile config, then match rules, then
+// the local default, then the global default. ok is false if nothing
+// resolves (e.g. no profiles configured, or no rule/default applies).
+func expectedIdentity() (Profile, bool, error) {
+	cfg, _, err := loadMergedConfig()
 	if err != nil {
-		return err
+		return Profile{}, false, err
 	}
 	if len(cfg.Profiles) == 0 {
-		return fmt.Errorf("no profiles configured; run `git-profile add` first")
+		return Profile{}, false, nil
 	}
 
-	// 1) Local default
+	if p, ok := localImplicitProfile(); ok {
+		return p, true, nil
+	}
+	if p, ok := matchProfile(cfg); ok {
+		return p, true, nil
+	}
 	if def, err := getGitConfig("gitprofile.default"); err == nil && def != "" {
 		if p, ok := cfg.Profiles[def]; ok {
-			return applyProfile(p, "local")
+			return p, true, nil
 		}
 	}
-
-	// 2) Global default
 	if gdef, err := getGitConfigGlobal("gitprofile.default"); err == nil && gdef != "" {
 		if p, ok := cfg.Profiles[gdef]; ok {
-			return applyProfile(p, "local")
+			return p, true, nil
 		}
 	}
 
-	// 3) Fallback: interactive
-	return cmdChoose(nil)
+	return Profile{}, false, nil
 }
 
-// install-hooks: installs prepare-commit-msg & pre-push hooks for this repo
+// ensure: used by the prepare-commit-msg and post-checkout hooks.
+// 1) If the repo-local .gitprofile.json defines exactly one profile -> apply it
+// 2) Else if a profile's Match rules match the remote URL or cwd -> apply it
+// 3) Else if local gitprofile.default exists and matches a profile -> apply it
+// 4) Else if global gitprofile.default exists and matches -> apply it
+// 5) Else -> interactive choose()
+func cmdEnsure(args []string) error {
+	_ = args
+
+	p, ok, err := expectedIdentity()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return cmdChoose(nil)
+	}
+
+	return applyProfile(p, "local")
+}
+
+// ----- Hook subcommands -----
+//
+// `install-hooks` wires plain git hooks to call these directly (dispatched
+// from main, just like any other command) instead of shelling out to a
+// single `ensure` snippet. prepare-commit-msg still just applies the
+// expected identity; pre-commit and pre-push are identity guards that
+// reject the operation when the configured identity doesn't match
+// what's expected (with gitprofile.autofix=true they apply the expected
+// profile instead of rejecting on that mismatch).
+
+// hook: dispatches to the prepare-commit-msg, pre-commit or pre-push
+// handler, mirroring how main() dispatches top-level commands.
+func cmdHook(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: git-profile hook <prepare-commit-msg|pre-commit|pre-push> [hook-args...]")
+	}
+
+	sub := args[0]
+	rest := args[1:]
+
+	switch sub {
+	case "prepare-commit-msg":
+		return cmdEnsure(nil)
+	case "pre-commit":
+		return cmdHookPreCommit(rest)
+	case "pre-push":
+		return cmdHookPrePush(rest)
+	default:
+		return fmt.Errorf("unknown hook: %s", sub)
+	}
+}
+
+// pre-commit: reject the commit if the repo's configured identity
+// doesn't match the expected profile. With no expected profile (no
+// match rule or default), the guard is a no-op.
+func cmdHookPreCommit(_ []string) error {
+	p, ok, err := expectedIdentity()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	return enforceIdentity(p)
+}
+
+// pre-push: in addition to the pre-commit guard, walks every commit
+// about to be pushed (per the pre-push stdin protocol: "<local-ref>
+// <local-oid> <remote-ref> <remote-oid>") and rejects the push if any
+// of them were authored under a different email than the expected
+// profile, so a shared machine can't push commits made under the wrong
+// identity even if the local git config is fixed up before pushing.
+func cmdHookPrePush(_ []string) error {
+	p, ok, err := expectedIdentity()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if err := enforceIdentity(p); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		localRef, localOID, _, remoteOID := fields[0], fields[1], fields[2], fields[3]
+
+		if isZeroOID(localOID) {
+			continue // deleting the ref: nothing new to check
+		}
+
+		emails, err := commitEmailsForPush(localOID, remoteOID)
+		if err != nil {
+			return fmt.Errorf("walking commits for %s: %w", localRef, err)
+		}
+
+		for _, email := range emails {
+			if !strings.EqualFold(email, p.GitEmail) {
+				return fmt.Errorf("refusing to push %s: commit authored as %s, expected %s (profile %q)", localRef, email, p.GitEmail, p.ID)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// commitEmailsForPush returns the author emails of the commits a push
+// would newly introduce for a single ref. When remoteOID is known, that's
+// exactly remoteOID..localOID. For a brand-new ref (remoteOID is the zero
+// OID) there's no remote tip to diff against, so it walks localOID but
+// excludes anything already reachable from a remote-tracking branch (e.g.
+// a feature branch cut from an already-pushed main) — otherwise every
+// new-branch push would get flagged for unrelated, already-public history.
+func commitEmailsForPush(localOID, remoteOID string) ([]string, error) {
+	logArgs := []string{"log", "--format=%ae"}
+	if isZeroOID(remoteOID) {
+		logArgs = append(logArgs, localOID, "--not", "--remotes")
+	} else {
+		logArgs = append(logArgs, remoteOID+".."+localOID)
+	}
+
+	out, err := exec.Command("git", logArgs...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// enforceIdentity compares the repo's configured user.name/user.email
+// against profile p, autofixing it if gitprofile.autofix=true and
+// otherwise failing with a message telling the user how to fix it.
+func enforceIdentity(p Profile) error {
+	name, _ := getGitConfig("user.name")
+	email, _ := getGitConfig("user.email")
+
+	if name == p.GitUser && email == p.GitEmail {
+		return nil
+	}
+
+	if autofix, _ := getGitConfig("gitprofile.autofix"); autofix == "true" {
+		if err := applyProfile(p, "local"); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "git-profile: autofixed identity to profile %q (%s <%s>)\n", p.ID, p.GitUser, p.GitEmail)
+		return nil
+	}
+
+	return fmt.Errorf("configured identity %s <%s> does not match expected profile %q (%s <%s>); run `git-profile use %s` or set gitprofile.autofix=true", name, email, p.ID, p.GitUser, p.GitEmail, p.ID)
+}
+
+func isZeroOID(oid string) bool {
+	if oid == "" {
+		return false
+	}
+	for _, c := range oid {
+		if c != '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// match: manage the remote/path glob rules used to auto-select a
+// profile in `ensure`. Repeated invocations append patterns.
+func cmdMatch(args []string) error {
+	fs := flag.NewFlagSet("match", flag.ExitOnError)
+	id := fs.String("add", "", "Profile ID to add match rules to")
+	remote := fs.String("remote", "", "Glob pattern matched against remote.origin.url")
+	path := fs.String("path", "", "Glob pattern matched against the working directory")
+	_ = fs.Parse(args)
+
+	if *id == "" {
+		return fmt.Errorf("usage: git-profile match --add <id> [--remote <pattern>] [--path <pattern>]")
+	}
+	if *remote == "" && *path == "" {
+		return fmt.Errorf("at least one of --remote or --path is required")
+	}
+
+	scope, err := profileScope(*id)
+	if err != nil {
+		return err
+	}
+
+	cfg, cfgPath, err := loadScopeConfig(scope)
+	if err != nil {
+		return err
+	}
+
+	p, ok := cfg.Profiles[*id]
+	if !ok {
+		return fmt.Errorf("profile %q not found", *id)
+	}
+	if p.Match == nil {
+		p.Match = &MatchRules{}
+	}
+
+	if *remote != "" {
+		p.Match.RemotePatterns = append(p.Match.RemotePatterns, *remote)
+	}
+	if *path != "" {
+		p.Match.PathPatterns = append(p.Match.PathPatterns, *path)
+	}
+	cfg.Profiles[*id] = p
+
+	if err := saveConfig(cfg, cfgPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added match rule(s) to profile %q\n", *id)
+	return nil
+}
+
+// install-hooks: installs prepare-commit-msg, pre-commit, pre-push &
+// post-checkout hooks for this repo. post-checkout lets a fresh clone into a matched
+// directory pick up the right identity immediately, with no prompt.
 func cmdInstallHooks(args []string) error {
 	_ = args
 
@@ -414,25 +1119,392 @@ func cmdInstallHooks(args []string) error {
 		return err
 	}
 
-	hookContent := `#!/bin/sh
-# git-profile hook: ensure correct profile before commit/push
+	hookScripts := map[string]string{
+		"prepare-commit-msg": `#!/bin/sh
+# git-profile hook: apply the matched/default identity before committing
+git-profile hook prepare-commit-msg "$@" >/dev/null 2>&1 || true
+`,
+		"pre-commit": `#!/bin/sh
+# git-profile hook: block commits authored under the wrong identity
+git-profile hook pre-commit "$@"
+`,
+		"pre-push": `#!/bin/sh
+# git-profile hook: block pushing commits authored under the wrong identity
+git-profile hook pre-push "$@"
+`,
+		"post-checkout": `#!/bin/sh
+# git-profile hook: apply the matched/default identity after checkout
 git-profile ensure >/dev/null 2>&1 || true
-`
+`,
+	}
 
-	hooks := []string{"prepare-commit-msg", "pre-push"}
+	hooks := []string{"prepare-commit-msg", "pre-commit", "pre-push", "post-checkout"}
 
 	for _, name := range hooks {
 		path := filepath.Join(hooksDir, name)
-		if err := os.WriteFile(path, []byte(hookContent), 0o755); err != nil {
+		if err := os.WriteFile(path, []byte(hookScripts[name]), 0o755); err != nil {
 			return fmt.Errorf("writing hook %s: %w", name, err)
 		}
 	}
 
 	fmt.Printf("Installed git-profile hooks in %s\n", hooksDir)
-	fmt.Println("From now on, normal `git commit` and `git push` will apply/ask for a profile.")
+	fmt.Println("From now on, normal `git commit` and `git push` will apply/ask for a profile, and commits/pushes under the wrong identity will be blocked.")
+	return nil
+}
+
+// ----- SSH key upload -----
+
+// ssh: dispatches ssh-related subcommands (currently just "upload").
+func cmdSSH(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: git-profile ssh upload --id <id> --host <host> [--provider github|gitlab|bitbucket] [--add-to-agent]")
+	}
+
+	switch args[0] {
+	case "upload":
+		return cmdSSHUpload(args[1:])
+	default:
+		return fmt.Errorf("unknown ssh subcommand: %s", args[0])
+	}
+}
+
+// ssh upload: uploads a profile's public key to a hosting provider,
+// mirroring the generate -> add-to-agent -> upload -> verify flow of
+// `gh auth login`.
+func cmdSSHUpload(args []string) error {
+	fs := flag.NewFlagSet("ssh upload", flag.ExitOnError)
+	id := fs.String("id", "", "Profile ID whose SSH key to upload")
+	host := fs.String("host", "", "Git hosting host, e.g. github.com")
+	provider := fs.String("provider", "", "Provider: github, gitlab or bitbucket (default: inferred from --host)")
+	addToAgent := fs.Bool("add-to-agent", false, "Add the private key to ssh-agent before uploading")
+	_ = fs.Parse(args)
+
+	if *id == "" || *host == "" {
+		return fmt.Errorf("usage: git-profile ssh upload --id <id> --host <host> [--provider github|gitlab|bitbucket] [--add-to-agent]")
+	}
+
+	prov := *provider
+	if prov == "" {
+		prov = inferSSHProvider(*host)
+	}
+	if prov == "" {
+		return fmt.Errorf("cannot infer provider from host %q; pass --provider", *host)
+	}
+
+	cfg, _, err := loadMergedConfig()
+	if err != nil {
+		return err
+	}
+	p, ok := cfg.Profiles[*id]
+	if !ok {
+		return fmt.Errorf("profile %q not found", *id)
+	}
+	if p.SSHKeyPath == "" {
+		return fmt.Errorf("profile %q has no ssh_key_path; generate one with `git-profile add --generate-ssh` or set one with `git-profile edit --ssh-key`", *id)
+	}
+
+	pub, err := os.ReadFile(p.SSHKeyPath + ".pub")
+	if err != nil {
+		return fmt.Errorf("reading public key: %w", err)
+	}
+	pubKey := strings.TrimSpace(string(pub))
+
+	if *addToAgent {
+		cmd := exec.Command("ssh-add", p.SSHKeyPath)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("ssh-add: %w", err)
+		}
+	}
+
+	token, err := sshProviderToken(prov)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("git-profile: %s", p.ID)
+	if err := sshProviderUploadKey(prov, token, title, pubKey); err != nil {
+		return fmt.Errorf("uploading key: %w", err)
+	}
+	fmt.Printf("Uploaded SSH key for profile %q to %s\n", *id, prov)
+
+	email, err := sshProviderVerifyEmail(prov, token)
+	if err != nil {
+		fmt.Printf("Warning: could not verify identity: %v\n", err)
+		return nil
+	}
+	if email != "" && !strings.EqualFold(email, p.GitEmail) {
+		fmt.Printf("Warning: %s account email (%s) does not match profile email (%s)\n", prov, email, p.GitEmail)
+	}
+
+	return nil
+}
+
+func inferSSHProvider(host string) string {
+	switch {
+	case strings.Contains(host, "github"):
+		return "github"
+	case strings.Contains(host, "gitlab"):
+		return "gitlab"
+	case strings.Contains(host, "bitbucket"):
+		return "bitbucket"
+	default:
+		return ""
+	}
+}
+
+// sshProviderToken reads credentials for provider, preferring an
+// explicit env var and falling back to `gh auth token` for GitHub.
+func sshProviderToken(provider string) (string, error) {
+	switch provider {
+	case "github":
+		if t := os.Getenv("GITHUB_TOKEN"); t != "" {
+			return t, nil
+		}
+		out, err := exec.Command("gh", "auth", "token").Output()
+		if err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+		return "", fmt.Errorf("no GitHub token found; set GITHUB_TOKEN or run `gh auth login`")
+	case "gitlab":
+		if t := os.Getenv("GITLAB_TOKEN"); t != "" {
+			return t, nil
+		}
+		return "", fmt.Errorf("no GitLab token found; set GITLAB_TOKEN")
+	case "bitbucket":
+		if t := os.Getenv("BITBUCKET_TOKEN"); t != "" {
+			return t, nil
+		}
+		return "", fmt.Errorf("no Bitbucket token found; set BITBUCKET_TOKEN")
+	default:
+		return "", fmt.Errorf("unsupported provider %q", provider)
+	}
+}
+
+type sshKeyUploadBody struct {
+	Title string `json:"title,omitempty"`
+	Label string `json:"label,omitempty"`
+	Key   string `json:"key"`
+}
+
+func sshProviderUploadKey(provider, token, title, pubKey string) error {
+	var req *http.Request
+	var err error
+
+	switch provider {
+	case "github":
+		body, _ := json.Marshal(sshKeyUploadBody{Title: title, Key: pubKey})
+		req, err = http.NewRequest(http.MethodPost, "https://api.github.com/user/keys", bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Authorization", "token "+token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}
+	case "gitlab":
+		body, _ := json.Marshal(sshKeyUploadBody{Title: title, Key: pubKey})
+		req, err = http.NewRequest(http.MethodPost, "https://gitlab.com/api/v4/user/keys", bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	case "bitbucket":
+		// Bitbucket Cloud namespaces SSH keys under the authenticated
+		// user, not a bare /2.0/user/ssh-keys shortcut.
+		username, uerr := bitbucketUsername(token)
+		if uerr != nil {
+			return fmt.Errorf("looking up bitbucket username: %w", uerr)
+		}
+		body, _ := json.Marshal(sshKeyUploadBody{Label: title, Key: pubKey})
+		uploadURL := fmt.Sprintf("https://api.bitbucket.org/2.0/users/%s/ssh-keys", url.PathEscape(username))
+		req, err = http.NewRequest(http.MethodPost, uploadURL, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	default:
+		return fmt.Errorf("unsupported provider %q", provider)
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned %s: %s", provider, resp.Status, strings.TrimSpace(string(b)))
+	}
 	return nil
 }
 
+type bitbucketUser struct {
+	Username string `json:"username"`
+}
+
+// bitbucketUsername looks up the authenticated user's username, needed
+// to address the user-scoped /2.0/users/{username}/... endpoints.
+func bitbucketUsername(token string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.bitbucket.org/2.0/user", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bitbucket returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	var u bitbucketUser
+	if err := json.NewDecoder(resp.Body).Decode(&u); err != nil {
+		return "", err
+	}
+	if u.Username == "" {
+		return "", fmt.Errorf("bitbucket user response had no username")
+	}
+	return u.Username, nil
+}
+
+type sshUserInfo struct {
+	Email string `json:"email"`
+}
+
+// sshProviderVerifyEmail hits the provider's "who am I" endpoint to
+// confirm the uploaded key's account matches the profile's email.
+func sshProviderVerifyEmail(provider, token string) (string, error) {
+	var req *http.Request
+	var err error
+
+	switch provider {
+	case "github":
+		req, err = http.NewRequest(http.MethodGet, "https://api.github.com/user", nil)
+		if err == nil {
+			req.Header.Set("Authorization", "token "+token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}
+	case "gitlab":
+		req, err = http.NewRequest(http.MethodGet, "https://gitlab.com/api/v4/user", nil)
+		if err == nil {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	case "bitbucket":
+		// Bitbucket's /2.0/user endpoint doesn't expose email; the
+		// primary address lives under /2.0/user/emails instead.
+		return bitbucketPrimaryEmail(token)
+	default:
+		return "", fmt.Errorf("unsupported provider %q", provider)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%s returned %s: %s", provider, resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	var info sshUserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	return info.Email, nil
+}
+
+type bitbucketEmails struct {
+	Values []struct {
+		Email     string `json:"email"`
+		IsPrimary bool   `json:"is_primary"`
+	} `json:"values"`
+}
+
+// bitbucketPrimaryEmail returns the authenticated user's primary email
+// address, used since /2.0/user doesn't expose it.
+func bitbucketPrimaryEmail(token string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.bitbucket.org/2.0/user/emails", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("bitbucket returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+
+	var emails bitbucketEmails
+	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
+		return "", err
+	}
+	for _, e := range emails.Values {
+		if e.IsPrimary {
+			return e.Email, nil
+		}
+	}
+	return "", fmt.Errorf("bitbucket account has no primary email")
+}
+
+// config: inspect the layered profile store. With --id, reports which
+// file a profile actually resolved from. With --where <scope>, reports
+// the file path used for that scope, whether or not it exists yet.
+func cmdConfig(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	where := fs.String("where", "", "Scope to show the config file path for: local, user or system")
+	id := fs.String("id", "", "Profile ID to show the resolved source of")
+	_ = fs.Parse(args)
+
+	if *id != "" {
+		scope, err := profileScope(*id)
+		if err != nil {
+			return err
+		}
+		path, err := scopeConfigPath(scope)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("profile %q comes from %s scope: %s\n", *id, scope, path)
+		return nil
+	}
+
+	if *where != "" {
+		if *where != "local" && *where != "user" && *where != "system" {
+			return fmt.Errorf("--where must be %q, %q or %q", "local", "user", "system")
+		}
+		path, err := scopeConfigPath(*where)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			fmt.Printf("%s scope: not available (not inside a git repository)\n", *where)
+			return nil
+		}
+		fmt.Printf("%s scope: %s\n", *where, path)
+		return nil
+	}
+
+	return fmt.Errorf("usage: git-profile config --where local|user|system, or git-profile config --id <profile-id>")
+}
+
 // ----- Usage / main -----
 
 func usage() {
@@ -440,23 +1512,37 @@ func usage() {
 
 Usage:
   git-profile add         --id <id> --name "<User Name>" --email "email@example.com" [--ssh-key /path/to/key]
+                          [--sign-key <key>] [--sign-format openpgp|ssh] [--sign] [--allowed-signers /path/to/file]
+                          [--generate-ssh [--ssh-type ed25519|rsa] [--ssh-comment <c>] [--ssh-passphrase <p>]]
+                          [--scope user|local|system]
+  git-profile edit        --id <id> [--name ...] [--email ...] [--ssh-key ...]
+                          [--sign-key ...] [--sign-format openpgp|ssh] [--sign] [--no-sign] [--allowed-signers ...]
   git-profile list
   git-profile use         [--global] <id>
   git-profile current
   git-profile choose
   git-profile set-default [--global] <id>
+  git-profile match       --add <id> [--remote '<glob>'] [--path '<glob>']
+  git-profile ssh upload  --id <id> --host <host> [--provider github|gitlab|bitbucket] [--add-to-agent]
+  git-profile config      --where local|user|system, or --id <id>
   git-profile ensure
+  git-profile hook        <prepare-commit-msg|pre-commit|pre-push>
   git-profile install-hooks
 
 Commands:
-  add           Add a new identity profile
+  add           Add a new identity profile (optionally generating an SSH keypair for it)
+  edit          Update fields on an existing identity profile
   list          List configured profiles
   use           Apply a profile to this repo or globally
   current       Show current git identity and defaults
   choose        Interactively choose a profile and apply locally
   set-default   Set per-repo or global default profile (stored in git config)
-  ensure        Apply repo default, then global default, otherwise prompt (used by hooks)
-  install-hooks Install hooks so plain 'git commit' and 'git push' call 'git-profile ensure'`)
+  match         Add remote-URL / working-directory match rules to a profile
+  ssh upload    Upload a profile's SSH public key to GitHub, GitLab or Bitbucket
+  config        Show where the layered profile config is read from
+  ensure        Apply the repo's sole local profile, then a matched profile, then repo/global default, otherwise prompt (used by hooks)
+  hook          Run a single git hook handler (used by installed hooks; pre-commit/pre-push block commits/pushes made under the wrong identity)
+  install-hooks Install hooks so plain 'git commit', 'git push' and 'git checkout' apply/guard the right identity`)
 }
 
 func main() {
@@ -473,6 +1559,8 @@ func main() {
 	switch cmd {
 	case "add":
 		err = cmdAdd(args)
+	case "edit":
+		err = cmdEdit(args)
 	case "list":
 		err = cmdList(args)
 	case "use":
@@ -483,6 +1571,14 @@ func main() {
 		err = cmdChoose(args)
 	case "set-default":
 		err = cmdSetDefault(args)
+	case "match":
+		err = cmdMatch(args)
+	case "ssh":
+		err = cmdSSH(args)
+	case "config":
+		err = cmdConfig(args)
+	case "hook":
+		err = cmdHook(args)
 	case "ensure":
 		err = cmdEnsure(args)
 	case "install-hooks":